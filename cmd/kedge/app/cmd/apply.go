@@ -0,0 +1,127 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/kedgeproject/kedge/pkg/statuscheck"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var (
+	applyWait    bool
+	applyTimeout time.Duration
+)
+
+// applyCmd runs `kubectl apply` against the Kubernetes artifacts generated
+// from the given kedge file(s), optionally blocking until they're Ready.
+var applyCmd = &cobra.Command{
+	Use:   "apply -f FILE",
+	Short: "Apply a kedge definition to a Kubernetes cluster",
+	RunE: func(c *cobra.Command, args []string) error {
+		objects, _, err := getRuntimeObjects()
+		if err != nil {
+			return errors.Wrap(err, "failed to transform kedge file(s)")
+		}
+
+		if err := kubectlApply(objects); err != nil {
+			return errors.Wrap(err, "failed to apply generated Kubernetes artifacts")
+		}
+
+		if !applyWait {
+			return nil
+		}
+
+		client, namespace, err := kubeClient()
+		if err != nil {
+			return errors.Wrap(err, "failed to set up Kubernetes client for --wait")
+		}
+
+		log.Infof("waiting up to %s for resources to become ready", applyTimeout)
+		if err := statuscheck.WaitForResources(client, namespace, objects, applyTimeout, statuscheck.DefaultPollInterval); err != nil {
+			return errors.Wrap(err, "resources did not become ready in time")
+		}
+		fmt.Fprintln(os.Stdout, "all resources are ready")
+		return nil
+	},
+}
+
+func init() {
+	applyCmd.Flags().StringSliceVarP(&inputFiles, "filename", "f", nil, "filename of the kedge definition(s) to apply")
+	applyCmd.Flags().BoolVar(&applyWait, "wait", false, "wait until all generated resources report Ready before returning")
+	applyCmd.Flags().DurationVar(&applyTimeout, "timeout", 5*time.Minute, "how long to wait for resources to become ready, only used with --wait")
+	RootCmd.AddCommand(applyCmd)
+}
+
+// kubectlApply marshals objects, the transformed Kubernetes manifests, and
+// pipes them into `kubectl apply -f -`. It deliberately does not apply
+// inputFiles directly: those are the un-transformed kedge definitions and
+// have neither apiVersion/kind nor a real Kubernetes object shape.
+func kubectlApply(objects []runtime.Object) error {
+	var buf bytes.Buffer
+	for i, obj := range objects {
+		if i > 0 {
+			fmt.Fprintln(&buf, "---")
+		}
+		out, err := yaml.Marshal(obj)
+		if err != nil {
+			return errors.Wrap(err, "unable to marshal generated object")
+		}
+		buf.Write(out)
+	}
+
+	kubectl := exec.Command("kubectl", "apply", "-f", "-")
+	kubectl.Stdin = &buf
+	kubectl.Stdout = os.Stdout
+	kubectl.Stderr = os.Stderr
+	return kubectl.Run()
+}
+
+// kubeClient sets up a Kubernetes clientset from the user's default
+// kubeconfig, the same one `kubectl` itself would use.
+func kubeClient() (kubernetes.Interface, string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{})
+
+	namespace, _, err := kubeConfig.Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+
+	restConfig, err := kubeConfig.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, namespace, nil
+}
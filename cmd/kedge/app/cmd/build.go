@@ -0,0 +1,161 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/kedgeproject/kedge/pkg/spec"
+	"github.com/kedgeproject/kedge/pkg/transform/kubernetes"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var (
+	overlayDir        string
+	emitKustomization bool
+)
+
+// buildCmd composes a base kedge file with an environment specific overlay
+// and prints the resulting Kubernetes manifest, mirroring `kustomize build`.
+var buildCmd = &cobra.Command{
+	Use:   "build -f FILE -o overlays/prod",
+	Short: "Build Kubernetes manifests from a kedge file and an environment overlay",
+	RunE: func(c *cobra.Command, args []string) error {
+		var app spec.App
+		for _, file := range inputFiles {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return errors.Wrapf(err, "unable to read file %q", file)
+			}
+			if err := yaml.Unmarshal(data, &app); err != nil {
+				return errors.Wrapf(err, "unable to parse file %q", file)
+			}
+		}
+
+		if overlayDir != "" {
+			overlay, err := loadOverlay(overlayDir)
+			if err != nil {
+				return errors.Wrapf(err, "unable to load overlay %q", overlayDir)
+			}
+			if err := spec.ApplyOverlay(&app, overlay); err != nil {
+				return errors.Wrap(err, "unable to apply overlay")
+			}
+		}
+
+		objects, _, err := kubernetes.Transform(&app)
+		if err != nil {
+			return errors.Wrap(err, "unable to transform kedge file(s)")
+		}
+
+		manifest, err := manifestYAML(objects)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(os.Stdout, manifest)
+
+		if emitKustomization {
+			return writeKustomization(overlayDir, manifest)
+		}
+		return nil
+	},
+}
+
+func init() {
+	buildCmd.Flags().StringSliceVarP(&inputFiles, "filename", "f", nil, "filename of the base kedge definition(s)")
+	buildCmd.Flags().StringVarP(&overlayDir, "overlay", "o", "", "directory holding the overlay to apply, e.g. overlays/prod")
+	buildCmd.Flags().BoolVar(&emitKustomization, "kustomization", false, "also write a kustomization.yaml next to the overlay referencing the base resources")
+	RootCmd.AddCommand(buildCmd)
+}
+
+// loadOverlay reads every *.yaml file in dir and merges them into a single
+// spec.Overlay.
+func loadOverlay(dir string) (*spec.Overlay, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	overlay := &spec.Overlay{}
+	for _, file := range matches {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read overlay file %q", file)
+		}
+		if err := yaml.Unmarshal(data, overlay); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse overlay file %q", file)
+		}
+	}
+	return overlay, nil
+}
+
+// manifestYAML marshals objects into a single multi-document YAML manifest,
+// the same format buildCmd prints to stdout.
+func manifestYAML(objects []runtime.Object) (string, error) {
+	var buf bytes.Buffer
+	for i, obj := range objects {
+		if i > 0 {
+			fmt.Fprintln(&buf, "---")
+		}
+		out, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", errors.Wrap(err, "unable to marshal generated object")
+		}
+		buf.Write(out)
+	}
+	return buf.String(), nil
+}
+
+// generatedManifestFile is the file writeKustomization writes the generated
+// Kubernetes manifest to, next to the kustomization.yaml referencing it.
+const generatedManifestFile = "kedge-generated.yaml"
+
+// writeKustomization writes the generated Kubernetes manifest to
+// overlayDir/generatedManifestFile and a minimal kustomization.yaml next to
+// it referencing that file, so this overlay slots into an existing
+// kustomize-based GitOps pipeline. Unlike the base kedge definitions (which
+// aren't valid Kubernetes resources on their own), the manifest kustomize
+// needs to read is the one kedge just generated.
+func writeKustomization(overlayDir, manifest string) error {
+	manifestPath := filepath.Join(overlayDir, generatedManifestFile)
+	if err := ioutil.WriteFile(manifestPath, []byte(manifest), 0644); err != nil {
+		return errors.Wrapf(err, "unable to write %q", manifestPath)
+	}
+
+	kustomization := struct {
+		APIVersion string   `json:"apiVersion"`
+		Kind       string   `json:"kind"`
+		Resources  []string `json:"resources,omitempty"`
+	}{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  []string{generatedManifestFile},
+	}
+
+	out, err := yaml.Marshal(kustomization)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal kustomization.yaml")
+	}
+	return ioutil.WriteFile(filepath.Join(overlayDir, "kustomization.yaml"), out, 0644)
+}
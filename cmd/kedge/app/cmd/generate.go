@@ -0,0 +1,104 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	kkubernetes "github.com/kedgeproject/kedge/pkg/kubernetes"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/pkg/api"
+)
+
+var generateFiles []string
+
+// generateCmd onboards plain Kubernetes manifests by folding them into a
+// single, compact kedge definition.
+var generateCmd = &cobra.Command{
+	Use:   "generate -f FILE",
+	Short: "Generate a kedge definition from existing Kubernetes manifests",
+	RunE: func(c *cobra.Command, args []string) error {
+		var objects []runtime.Object
+		for _, file := range generateFiles {
+			objs, err := decodeManifestFile(file)
+			if err != nil {
+				return err
+			}
+			objects = append(objects, objs...)
+		}
+
+		app, err := kkubernetes.Reverse(objects)
+		if err != nil {
+			return errors.Wrap(err, "unable to generate kedge definition")
+		}
+
+		out, err := yaml.Marshal(app)
+		if err != nil {
+			return errors.Wrap(err, "unable to marshal generated kedge definition")
+		}
+		fmt.Fprint(os.Stdout, string(out))
+		return nil
+	},
+}
+
+func init() {
+	generateCmd.Flags().StringSliceVarP(&generateFiles, "filename", "f", nil, "filename of the Kubernetes manifest(s) to generate a kedge definition from")
+	RootCmd.AddCommand(generateCmd)
+}
+
+// decodeManifestFile reads file and decodes every "---"-separated document
+// in it. ghodss/yaml (and api.Codecs.UniversalDeserializer().Decode fed raw
+// file bytes) only ever decodes the first document in a multi-document YAML
+// stream and silently drops the rest, which is exactly the shape a combined
+// manifest file takes in the wild.
+func decodeManifestFile(file string) ([]runtime.Object, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read file %q", file)
+	}
+
+	var objects []runtime.Object
+	decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		var raw runtime.RawExtension
+		if err := decoder.Decode(&raw); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, errors.Wrapf(err, "unable to parse Kubernetes manifest %q", file)
+		}
+		if len(bytes.TrimSpace(raw.Raw)) == 0 {
+			continue
+		}
+
+		obj, _, err := api.Codecs.UniversalDeserializer().Decode(raw.Raw, nil, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse Kubernetes manifest %q", file)
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd wires up the `kedge` command line tool.
+package cmd
+
+import (
+	"io/ioutil"
+
+	"github.com/kedgeproject/kedge/pkg/spec"
+	"github.com/kedgeproject/kedge/pkg/transform/kubernetes"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RootCmd is the base `kedge` command that every subcommand attaches to.
+var RootCmd = &cobra.Command{
+	Use:   "kedge",
+	Short: "kedge simplifies Kubernetes application definitions",
+}
+
+// inputFiles holds the -f/--filename values shared by the subcommands that
+// consume kedge definitions (apply, build). generate reads Kubernetes
+// manifests instead and keeps its own generateFiles.
+var inputFiles []string
+
+// getRuntimeObjects reads inputFiles, merges them into a single spec.App and
+// transforms it into the Kubernetes objects that would be applied or
+// written out.
+func getRuntimeObjects() ([]runtime.Object, []string, error) {
+	var app spec.App
+
+	for _, file := range inputFiles {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "unable to read file %q", file)
+		}
+		if err := yaml.Unmarshal(data, &app); err != nil {
+			return nil, nil, errors.Wrapf(err, "unable to parse file %q", file)
+		}
+	}
+
+	return kubernetes.Transform(&app)
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kubernetes folds plain Kubernetes manifests back into a kedge
+// spec.App, the inverse of pkg/transform/kubernetes. This lets users onboard
+// existing manifests with `kedge generate` instead of hand writing a kedge
+// file from scratch.
+package kubernetes
+
+import (
+	"fmt"
+
+	"github.com/kedgeproject/kedge/pkg/spec"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+	ext_v1beta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+// Reverse consumes a set of standard Kubernetes manifests - a Deployment
+// plus any Services, Ingresses, ConfigMaps, Secrets and
+// PersistentVolumeClaims that belong to it - and folds them into a single
+// compact kedge spec.App. It is the inverse of Transform/CreateK8sObjects:
+// whatever those helpers expand out of an App, Reverse collapses back in.
+func Reverse(objects []runtime.Object) (*spec.App, error) {
+	app := &spec.App{}
+
+	var deployment *ext_v1beta1.Deployment
+	var ingresses []*ext_v1beta1.Ingress
+	pvcs := map[string]*api_v1.PersistentVolumeClaim{}
+
+	for _, obj := range objects {
+		switch o := obj.(type) {
+		case *ext_v1beta1.Deployment:
+			if deployment != nil {
+				return nil, fmt.Errorf("cannot reverse more than one Deployment into a single app")
+			}
+			deployment = o
+		case *api_v1.Service:
+			reverseService(app, o)
+		case *ext_v1beta1.Ingress:
+			ingresses = append(ingresses, o)
+		case *api_v1.ConfigMap:
+			app.ConfigMaps = append(app.ConfigMaps, spec.ConfigMapMod{
+				Name: o.Name,
+				Data: o.Data,
+			})
+		case *api_v1.Secret:
+			app.Secrets = append(app.Secrets, spec.SecretMod{
+				Name:       o.Name,
+				Data:       o.Data,
+				StringData: o.StringData,
+				Type:       o.Type,
+			})
+		case *api_v1.PersistentVolumeClaim:
+			pvcs[o.Name] = o
+		default:
+			return nil, fmt.Errorf("cannot reverse object of kind %T", obj)
+		}
+	}
+
+	if deployment == nil {
+		return nil, errors.New("no Deployment found in the given objects, cannot derive a kedge app")
+	}
+
+	app.Name = deployment.Name
+	app.Labels = deployment.Labels
+
+	podSpec := deployment.Spec.Template.Spec
+	reverseVolumeClaims(app, &podSpec, pvcs)
+	app.Volumes = podSpec.Volumes
+	reverseIngresses(app, ingresses)
+
+	for _, c := range podSpec.Containers {
+		app.Containers = append(app.Containers, reverseContainer(c, app))
+	}
+
+	return app, nil
+}
+
+// reverseService turns a Service back into app.Services, re-detecting the
+// kedge "endpoint" shorthand once the matching Ingress is seen in
+// reverseIngresses.
+func reverseService(app *spec.App, svc *api_v1.Service) {
+	s := spec.ServiceSpecMod{
+		Name:        svc.Name,
+		ServiceSpec: svc.Spec,
+	}
+	for _, p := range svc.Spec.Ports {
+		s.Ports = append(s.Ports, spec.ServicePortMod{ServicePort: p})
+	}
+	app.Services = append(app.Services, s)
+}
+
+// reverseIngresses folds every Ingress with a single host/path rule backing
+// one of app.Services back into that service port's Endpoint shorthand.
+// Anything more complex (multiple rules, multiple paths, TLS, ...) is kept
+// as a standalone entry in app.Ingresses.
+func reverseIngresses(app *spec.App, ingresses []*ext_v1beta1.Ingress) {
+	for _, ing := range ingresses {
+		if host, path, backend, ok := singleRuleBackend(ing); ok {
+			if collapseIntoEndpoint(app, host, path, backend) {
+				continue
+			}
+		}
+		app.Ingresses = append(app.Ingresses, spec.IngressSpecMod{
+			Name:        ing.Name,
+			IngressSpec: ing.Spec,
+		})
+	}
+}
+
+func singleRuleBackend(ing *ext_v1beta1.Ingress) (host, path string, backend ext_v1beta1.IngressBackend, ok bool) {
+	if len(ing.Spec.TLS) > 0 || ing.Spec.Backend != nil {
+		return "", "", ext_v1beta1.IngressBackend{}, false
+	}
+	if len(ing.Spec.Rules) != 1 || ing.Spec.Rules[0].HTTP == nil || len(ing.Spec.Rules[0].HTTP.Paths) != 1 {
+		return "", "", ext_v1beta1.IngressBackend{}, false
+	}
+	rule := ing.Spec.Rules[0]
+	return rule.Host, rule.HTTP.Paths[0].Path, rule.HTTP.Paths[0].Backend, true
+}
+
+// collapseIntoEndpoint sets Endpoint on the ServicePortMod matching backend,
+// reporting whether a match was found.
+func collapseIntoEndpoint(app *spec.App, host, path string, backend ext_v1beta1.IngressBackend) bool {
+	endpoint := host
+	if path != "" && path != "/" {
+		endpoint = host + path
+	}
+
+	for si, svc := range app.Services {
+		if svc.Name != backend.ServiceName {
+			continue
+		}
+		for pi, port := range svc.Ports {
+			if port.Port == backend.ServicePort.IntVal {
+				app.Services[si].Ports[pi].Endpoint = endpoint
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reverseVolumeClaims finds PersistentVolumeClaim + Volume + VolumeMount
+// triples and collapses them into root level app.VolumeClaims, removing the
+// pod level Volume entry since it is regenerated by populateVolumes.
+func reverseVolumeClaims(app *spec.App, podSpec *api_v1.PodSpec, pvcs map[string]*api_v1.PersistentVolumeClaim) {
+	claimedByVolume := map[string]string{}
+	for _, v := range podSpec.Volumes {
+		if v.PersistentVolumeClaim != nil {
+			claimedByVolume[v.Name] = v.PersistentVolumeClaim.ClaimName
+		}
+	}
+
+	var remaining []api_v1.Volume
+	for _, v := range podSpec.Volumes {
+		claim, isClaim := claimedByVolume[v.Name]
+		pvc, known := pvcs[claim]
+		if !isClaim || !known {
+			remaining = append(remaining, v)
+			continue
+		}
+
+		vc := spec.VolumeClaim{
+			Name:                      pvc.Name,
+			PersistentVolumeClaimSpec: pvc.Spec,
+		}
+		if size, ok := pvc.Spec.Resources.Requests[api_v1.ResourceStorage]; ok {
+			vc.Size = size.String()
+			vc.Resources = api_v1.ResourceRequirements{}
+		}
+		app.VolumeClaims = append(app.VolumeClaims, vc)
+	}
+	podSpec.Volumes = remaining
+}
+
+// reverseContainer turns a plain api_v1.Container back into a
+// ContainerSpecMod, re-detecting env vars that were expanded from
+// app.ConfigMaps/app.Secrets via EnvFrom and re-collapsing them.
+func reverseContainer(c api_v1.Container, app *spec.App) spec.ContainerSpecMod {
+	mod := spec.ContainerSpecMod{Container: c}
+
+	if c.LivenessProbe != nil && c.ReadinessProbe != nil && c.LivenessProbe.String() == c.ReadinessProbe.String() {
+		mod.Health = c.LivenessProbe
+		mod.LivenessProbe = nil
+		mod.ReadinessProbe = nil
+	}
+
+	var envFrom []spec.EnvFromMod
+	var plainEnv []api_v1.EnvVar
+	consumed := map[string]bool{}
+
+	for _, cm := range app.ConfigMaps {
+		if allKeysReferenced(c.Env, cm.Data, configMapKey) {
+			envFrom = append(envFrom, spec.EnvFromMod{ConfigMapRef: &spec.ConfigMapEnvSource{Name: cm.Name}})
+			for k := range cm.Data {
+				consumed[k] = true
+			}
+		}
+	}
+	for _, s := range app.Secrets {
+		keys := secretKeys(s)
+		if allKeysReferenced(c.Env, keys, secretKey) {
+			envFrom = append(envFrom, spec.EnvFromMod{SecretRef: &spec.SecretEnvSource{Name: s.Name}})
+			for _, k := range keys {
+				consumed[k] = true
+			}
+		}
+	}
+
+	for _, e := range c.Env {
+		if !consumed[e.Name] {
+			plainEnv = append(plainEnv, e)
+		}
+	}
+
+	mod.EnvFrom = envFrom
+	mod.Env = plainEnv
+	return mod
+}
+
+func secretKeys(s spec.SecretMod) []string {
+	var keys []string
+	for k := range s.Data {
+		keys = append(keys, k)
+	}
+	for k := range s.StringData {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func configMapKey(e api_v1.EnvVar) string {
+	if e.ValueFrom == nil || e.ValueFrom.ConfigMapKeyRef == nil {
+		return ""
+	}
+	return e.ValueFrom.ConfigMapKeyRef.Key
+}
+
+func secretKey(e api_v1.EnvVar) string {
+	if e.ValueFrom == nil || e.ValueFrom.SecretKeyRef == nil {
+		return ""
+	}
+	return e.ValueFrom.SecretKeyRef.Key
+}
+
+// allKeysReferenced reports whether every key in source has a matching env
+// var in env whose value comes from keyOf.
+func allKeysReferenced(env []api_v1.EnvVar, source interface{}, keyOf func(api_v1.EnvVar) string) bool {
+	var keys []string
+	switch src := source.(type) {
+	case map[string]string:
+		for k := range src {
+			keys = append(keys, k)
+		}
+	case []string:
+		keys = src
+	}
+	if len(keys) == 0 {
+		return false
+	}
+
+	for _, k := range keys {
+		found := false
+		for _, e := range env {
+			if keyOf(e) == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
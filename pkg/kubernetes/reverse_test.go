@@ -0,0 +1,165 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+	ext_v1beta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+func TestReverseNoDeployment(t *testing.T) {
+	_, err := Reverse([]runtime.Object{&api_v1.Service{}})
+	if err == nil {
+		t.Fatal("expected an error when no Deployment is present, got nil")
+	}
+}
+
+func TestReverseMultipleDeployments(t *testing.T) {
+	objects := []runtime.Object{
+		&ext_v1beta1.Deployment{ObjectMeta: meta("foo")},
+		&ext_v1beta1.Deployment{ObjectMeta: meta("bar")},
+	}
+	_, err := Reverse(objects)
+	if err == nil {
+		t.Fatal("expected an error when more than one Deployment is given, got nil")
+	}
+}
+
+func TestReverseContainers(t *testing.T) {
+	deployment := &ext_v1beta1.Deployment{
+		ObjectMeta: meta("app1"),
+		Spec: ext_v1beta1.DeploymentSpec{
+			Template: api_v1.PodTemplateSpec{
+				Spec: api_v1.PodSpec{
+					Containers: []api_v1.Container{
+						{Name: "c1", Image: "nginx"},
+					},
+				},
+			},
+		},
+	}
+
+	app, err := Reverse([]runtime.Object{deployment})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if app.Name != "app1" {
+		t.Errorf("expected app.Name %q, got %q", "app1", app.Name)
+	}
+	if len(app.Containers) != 1 || app.Containers[0].Name != "c1" {
+		t.Fatalf("expected a single container named %q, got %+v", "c1", app.Containers)
+	}
+}
+
+func TestReverseVolumeClaims(t *testing.T) {
+	podSpec := api_v1.PodSpec{
+		Containers: []api_v1.Container{
+			{
+				Name: "c1",
+				VolumeMounts: []api_v1.VolumeMount{
+					{Name: "data", MountPath: "/data"},
+				},
+			},
+		},
+		Volumes: []api_v1.Volume{
+			{
+				Name: "data",
+				VolumeSource: api_v1.VolumeSource{
+					PersistentVolumeClaim: &api_v1.PersistentVolumeClaimVolumeSource{ClaimName: "data"},
+				},
+			},
+		},
+	}
+	deployment := &ext_v1beta1.Deployment{
+		ObjectMeta: meta("app1"),
+		Spec:       ext_v1beta1.DeploymentSpec{Template: api_v1.PodTemplateSpec{Spec: podSpec}},
+	}
+	pvc := &api_v1.PersistentVolumeClaim{ObjectMeta: meta("data")}
+
+	app, err := Reverse([]runtime.Object{deployment, pvc})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(app.VolumeClaims) != 1 || app.VolumeClaims[0].Name != "data" {
+		t.Fatalf("expected a single volume claim named %q, got %+v", "data", app.VolumeClaims)
+	}
+	if len(app.Containers[0].VolumeMounts) != 1 {
+		t.Fatalf("expected the container's volume mount to survive reversal")
+	}
+}
+
+func TestReverseEnvFromConfigMap(t *testing.T) {
+	deployment := &ext_v1beta1.Deployment{
+		ObjectMeta: meta("app1"),
+		Spec: ext_v1beta1.DeploymentSpec{
+			Template: api_v1.PodTemplateSpec{
+				Spec: api_v1.PodSpec{
+					Containers: []api_v1.Container{
+						{
+							Name: "c1",
+							Env: []api_v1.EnvVar{
+								{
+									Name: "FOO",
+									ValueFrom: &api_v1.EnvVarSource{
+										ConfigMapKeyRef: &api_v1.ConfigMapKeySelector{
+											LocalObjectReference: api_v1.LocalObjectReference{Name: "cfg"},
+											Key:                  "FOO",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	configMap := &api_v1.ConfigMap{
+		ObjectMeta: meta("cfg"),
+		Data:       map[string]string{"FOO": "bar"},
+	}
+
+	app, err := Reverse([]runtime.Object{deployment, configMap})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := app.Containers[0]
+	if len(c.Env) != 0 {
+		t.Errorf("expected the env var consumed by EnvFrom to be dropped, got %+v", c.Env)
+	}
+	if len(c.EnvFrom) != 1 || c.EnvFrom[0].ConfigMapRef == nil || c.EnvFrom[0].ConfigMapRef.Name != "cfg" {
+		t.Fatalf("expected a single configMapRef named %q, got %+v", "cfg", c.EnvFrom)
+	}
+}
+
+func TestReverseUnknownKind(t *testing.T) {
+	_, err := Reverse([]runtime.Object{&api_v1.Pod{}})
+	if err == nil {
+		t.Fatal("expected an error for an object kind Reverse doesn't support, got nil")
+	}
+}
+
+func meta(name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Name: name}
+}
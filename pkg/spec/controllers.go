@@ -0,0 +1,47 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	appsv1beta1 "k8s.io/client-go/pkg/apis/apps/v1beta1"
+	batchv1 "k8s.io/client-go/pkg/apis/batch/v1"
+	batchv2alpha1 "k8s.io/client-go/pkg/apis/batch/v2alpha1"
+	ext_v1beta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+// StatefulSetSpecMod is app.StatefulSet. Like app.DeploymentSpec, the top
+// level PodSpec merges into Template.Spec; root level VolumeClaims marked
+// PerReplica are auto-wired into VolumeClaimTemplates.
+type StatefulSetSpecMod struct {
+	appsv1beta1.StatefulSetSpec `json:",inline"`
+}
+
+// DaemonSetSpecMod is app.DaemonSet. A DaemonSet runs one Pod per node, so
+// app.Replicas must not be set when this controller is used.
+type DaemonSetSpecMod struct {
+	ext_v1beta1.DaemonSetSpec `json:",inline"`
+}
+
+// JobSpecMod is app.Job.
+type JobSpecMod struct {
+	batchv1.JobSpec `json:",inline"`
+}
+
+// CronJobSpecMod is app.CronJob. Schedule is required.
+type CronJobSpecMod struct {
+	batchv2alpha1.CronJobSpec `json:",inline"`
+}
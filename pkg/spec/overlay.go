@@ -0,0 +1,71 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+)
+
+// Overlay is a kustomize-style, environment specific patch that gets
+// deep-merged into a base App before Transform runs. A kedge file loaded
+// with `kedge build -o overlays/prod` reads its base App as usual and then
+// applies the matching Overlay on top.
+type Overlay struct {
+	// Replicas overrides app.DeploymentSpec.Replicas (or the equivalent
+	// field on whichever controller the base app uses).
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Images overrides a container's image by name, e.g.
+	// {"web": "myrepo/web:v2"}.
+	Images map[string]string `json:"images,omitempty"`
+
+	// Env patches in additional environment variables by container name.
+	// Entries with a name matching an existing Env entry on that container
+	// replace it; new names are appended.
+	Env map[string][]api_v1.EnvVar `json:"env,omitempty"`
+
+	// Resources overrides a container's resource requests/limits by name.
+	Resources map[string]api_v1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Labels and Annotations are merged into the base app's, overlay
+	// values winning on key collisions.
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Volumes are appended to the base app's pod level volumes.
+	Volumes []api_v1.Volume `json:"volumes,omitempty"`
+
+	// Containers is a strategic merge patch keyed by container name,
+	// walked the same way populateContainerHealth walks app.Containers:
+	// an entry here with a name matching a base container has its image,
+	// command, args, env and health merged into it; its other fields are
+	// ignored. An entry with a new name is appended.
+	Containers []ContainerSpecMod `json:"containers,omitempty"`
+
+	// Patches holds surgical, JSON-patch style edits (RFC 6902 op/path/
+	// value triples) applied after every field above, for anything the
+	// structured fields don't cover.
+	Patches []PatchOp `json:"patches,omitempty"`
+}
+
+// PatchOp is a single RFC 6902 style operation applied to the App after it
+// has been marshaled to JSON. Op is one of "add", "replace" or "remove".
+type PatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+)
+
+// ApplyOverlay deep-merges overlay into app, in place. It is meant to run
+// right after a base kedge file is loaded and before Transform runs.
+func ApplyOverlay(app *App, overlay *Overlay) error {
+	if overlay.Replicas != nil {
+		setReplicas(app, *overlay.Replicas)
+	}
+
+	mergeContainers(app, overlay)
+
+	if app.Labels == nil && len(overlay.Labels) > 0 {
+		app.Labels = map[string]string{}
+	}
+	for k, v := range overlay.Labels {
+		app.Labels[k] = v
+	}
+
+	if app.Annotations == nil && len(overlay.Annotations) > 0 {
+		app.Annotations = map[string]string{}
+	}
+	for k, v := range overlay.Annotations {
+		app.Annotations[k] = v
+	}
+
+	app.Volumes = append(app.Volumes, overlay.Volumes...)
+
+	for _, c := range overlay.Containers {
+		mergeContainer(app, c)
+	}
+
+	return applyPatches(app, overlay.Patches)
+}
+
+// setReplicas overrides the replica count on whichever controller app.Controller
+// selects. Deployment and StatefulSet are the only controllers with a
+// meaningful replica count; a bare Pod is a single Pod, DaemonSet runs one Pod
+// per node and Job/CronJob don't have one at all, so an overlay setting
+// Replicas for those is a no-op.
+// Every case is listed explicitly rather than funneling unrecognized values
+// into the Deployment branch, since app.DeploymentSpec.Replicas != nil is
+// rejected outright by pkg/transform/kubernetes's DaemonSet controller.
+func setReplicas(app *App, replicas int32) {
+	switch app.Controller {
+	case "", "deployment":
+		app.DeploymentSpec.Replicas = &replicas
+	case "statefulset":
+		app.StatefulSet.Replicas = &replicas
+	case "pod", "daemonset", "job", "cronjob":
+		// no-op: see the doc comment above.
+	}
+}
+
+// mergeContainers applies overlay.Images/Env/Resources to the base app's
+// containers, matched by name.
+func mergeContainers(app *App, overlay *Overlay) {
+	for ci, c := range app.Containers {
+		if image, ok := overlay.Images[c.Name]; ok {
+			app.Containers[ci].Image = image
+		}
+		if resources, ok := overlay.Resources[c.Name]; ok {
+			app.Containers[ci].Resources = resources
+		}
+		if env, ok := overlay.Env[c.Name]; ok {
+			app.Containers[ci].Env = mergeEnv(c.Env, env)
+		}
+	}
+}
+
+// mergeEnv overlays patch on top of base, replacing entries with a matching
+// Name and appending the rest.
+func mergeEnv(base, patch []api_v1.EnvVar) []api_v1.EnvVar {
+	merged := append([]api_v1.EnvVar{}, base...)
+	for _, e := range patch {
+		replaced := false
+		for i, existing := range merged {
+			if existing.Name == e.Name {
+				merged[i] = e
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, e)
+		}
+	}
+	return merged
+}
+
+// mergeContainer strategic-merges a single overlay container into app,
+// matched by name: Image, Command, Args and Health win when the overlay sets
+// them, Env is merged by variable name, and every other field of c is
+// ignored. A name with no match in the base app is appended as a new
+// container.
+func mergeContainer(app *App, c ContainerSpecMod) {
+	for i, base := range app.Containers {
+		if base.Name != c.Name {
+			continue
+		}
+		if c.Image != "" {
+			app.Containers[i].Image = c.Image
+		}
+		if len(c.Command) > 0 {
+			app.Containers[i].Command = c.Command
+		}
+		if len(c.Args) > 0 {
+			app.Containers[i].Args = c.Args
+		}
+		if len(c.Env) > 0 {
+			app.Containers[i].Env = mergeEnv(base.Env, c.Env)
+		}
+		if c.Health != nil {
+			app.Containers[i].Health = c.Health
+		}
+		return
+	}
+	app.Containers = append(app.Containers, c)
+}
+
+// applyPatches applies RFC 6902 style add/replace/remove operations to app,
+// by round tripping it through JSON. This covers the surgical edits that
+// the structured Overlay fields above don't.
+func applyPatches(app *App, patches []PatchOp) error {
+	if len(patches) == 0 {
+		return nil
+	}
+
+	raw, err := json.Marshal(app)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal app before applying patches")
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return errors.Wrap(err, "unable to decode app before applying patches")
+	}
+
+	for _, p := range patches {
+		if err := applyPatch(doc, p); err != nil {
+			return errors.Wrapf(err, "patch %+v", p)
+		}
+	}
+
+	patched, err := json.Marshal(doc)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal patched app")
+	}
+
+	*app = App{}
+	if err := json.Unmarshal(patched, app); err != nil {
+		return errors.Wrap(err, "unable to decode patched app")
+	}
+	return nil
+}
+
+// applyPatch applies a single JSON-patch style operation against doc, a
+// generic decoded-JSON tree. Only "add", "replace" and "remove" are
+// supported, which is all the structured Overlay fields leave uncovered.
+// Unlike RFC 6902, "add" on an array index overwrites the element there
+// instead of inserting, and "remove" only works on object keys.
+func applyPatch(doc map[string]interface{}, p PatchOp) error {
+	segments := strings.Split(strings.Trim(p.Path, "/"), "/")
+	if len(segments) == 0 || segments[0] == "" {
+		return errors.Errorf("invalid path %q", p.Path)
+	}
+
+	parent, key, err := walkToParent(doc, segments)
+	if err != nil {
+		return err
+	}
+
+	switch p.Op {
+	case "add", "replace":
+		return setAt(parent, key, p.Value)
+	case "remove":
+		return removeAt(parent, key)
+	default:
+		return errors.Errorf("unsupported patch op %q", p.Op)
+	}
+}
+
+// walkToParent walks doc down to the parent container of the final path
+// segment, returning that container and the final segment as the key to
+// read/write on it.
+func walkToParent(doc map[string]interface{}, segments []string) (interface{}, string, error) {
+	var cur interface{} = doc
+	for _, seg := range segments[:len(segments)-1] {
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			next, ok := v[seg]
+			if !ok {
+				return nil, "", errors.Errorf("path segment %q not found", seg)
+			}
+			cur = next
+		case []interface{}:
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, "", errors.Errorf("invalid array index %q", seg)
+			}
+			cur = v[idx]
+		default:
+			return nil, "", errors.Errorf("cannot descend into %q", seg)
+		}
+	}
+	return cur, segments[len(segments)-1], nil
+}
+
+func setAt(container interface{}, key string, value interface{}) error {
+	switch v := container.(type) {
+	case map[string]interface{}:
+		v[key] = value
+		return nil
+	case []interface{}:
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 || idx >= len(v) {
+			return errors.Errorf("invalid array index %q", key)
+		}
+		v[idx] = value
+		return nil
+	default:
+		return errors.Errorf("cannot set %q on %T", key, container)
+	}
+}
+
+func removeAt(container interface{}, key string) error {
+	switch v := container.(type) {
+	case map[string]interface{}:
+		delete(v, key)
+		return nil
+	default:
+		return errors.Errorf("cannot remove %q from %T", key, container)
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"testing"
+
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+)
+
+func TestApplyOverlayReplicasDeployment(t *testing.T) {
+	app := &App{Name: "app1"}
+	replicas := int32(3)
+
+	if err := ApplyOverlay(app, &Overlay{Replicas: &replicas}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if app.DeploymentSpec.Replicas == nil || *app.DeploymentSpec.Replicas != 3 {
+		t.Fatalf("expected app.DeploymentSpec.Replicas == 3, got %+v", app.DeploymentSpec.Replicas)
+	}
+}
+
+func TestApplyOverlayReplicasStatefulSet(t *testing.T) {
+	app := &App{Name: "app1", Controller: "statefulset"}
+	replicas := int32(3)
+
+	if err := ApplyOverlay(app, &Overlay{Replicas: &replicas}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if app.StatefulSet.Replicas == nil || *app.StatefulSet.Replicas != 3 {
+		t.Fatalf("expected app.StatefulSet.Replicas == 3, got %+v", app.StatefulSet.Replicas)
+	}
+	if app.DeploymentSpec.Replicas != nil {
+		t.Fatalf("expected app.DeploymentSpec.Replicas to be untouched, got %+v", app.DeploymentSpec.Replicas)
+	}
+}
+
+// TestApplyOverlayReplicasDaemonSet guards against regressing setReplicas
+// into funneling daemonset/job/cronjob through the Deployment branch: a
+// DaemonSet controller rejects any non-nil app.DeploymentSpec.Replicas, so
+// an overlay setting replicas against a daemonset app must stay a no-op.
+func TestApplyOverlayReplicasDaemonSet(t *testing.T) {
+	app := &App{Name: "app1", Controller: "daemonset"}
+	replicas := int32(3)
+
+	if err := ApplyOverlay(app, &Overlay{Replicas: &replicas}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if app.DeploymentSpec.Replicas != nil {
+		t.Fatalf("expected replicas to be a no-op for controller %q, got %+v", "daemonset", app.DeploymentSpec.Replicas)
+	}
+	if app.StatefulSet.Replicas != nil {
+		t.Fatalf("expected app.StatefulSet.Replicas to be untouched, got %+v", app.StatefulSet.Replicas)
+	}
+}
+
+func TestMergeContainersImageAndEnv(t *testing.T) {
+	app := &App{
+		Containers: []ContainerSpecMod{
+			{Container: api_v1.Container{
+				Name:  "web",
+				Image: "myrepo/web:v1",
+				Env:   []api_v1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}},
+			}},
+		},
+	}
+	overlay := &Overlay{
+		Images: map[string]string{"web": "myrepo/web:v2"},
+		Env: map[string][]api_v1.EnvVar{
+			"web": {{Name: "LOG_LEVEL", Value: "info"}, {Name: "NEW_VAR", Value: "x"}},
+		},
+	}
+
+	if err := ApplyOverlay(app, overlay); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := app.Containers[0]
+	if c.Image != "myrepo/web:v2" {
+		t.Errorf("expected image %q, got %q", "myrepo/web:v2", c.Image)
+	}
+	if len(c.Env) != 2 {
+		t.Fatalf("expected 2 env vars after merge, got %+v", c.Env)
+	}
+	if c.Env[0].Value != "info" {
+		t.Errorf("expected LOG_LEVEL to be replaced with %q, got %q", "info", c.Env[0].Value)
+	}
+	if c.Env[1].Name != "NEW_VAR" {
+		t.Errorf("expected NEW_VAR to be appended, got %+v", c.Env[1])
+	}
+}
+
+func TestMergeContainerAppendsNewContainer(t *testing.T) {
+	app := &App{Containers: []ContainerSpecMod{{Container: api_v1.Container{Name: "web"}}}}
+	overlay := &Overlay{Containers: []ContainerSpecMod{{Container: api_v1.Container{Name: "sidecar", Image: "envoy"}}}}
+
+	if err := ApplyOverlay(app, overlay); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(app.Containers) != 2 || app.Containers[1].Name != "sidecar" {
+		t.Fatalf("expected the overlay container to be appended, got %+v", app.Containers)
+	}
+}
+
+func TestApplyPatchesReplace(t *testing.T) {
+	app := &App{Name: "app1"}
+	overlay := &Overlay{
+		Patches: []PatchOp{
+			{Op: "replace", Path: "/name", Value: "app2"},
+		},
+	}
+
+	if err := ApplyOverlay(app, overlay); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if app.Name != "app2" {
+		t.Errorf("expected name to be patched to %q, got %q", "app2", app.Name)
+	}
+}
+
+func TestApplyPatchesRemove(t *testing.T) {
+	app := &App{Name: "app1", Labels: map[string]string{"tier": "web"}}
+	overlay := &Overlay{
+		Patches: []PatchOp{
+			{Op: "remove", Path: "/labels/tier"},
+		},
+	}
+
+	if err := ApplyOverlay(app, overlay); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := app.Labels["tier"]; ok {
+		t.Errorf("expected label %q to be removed, got %+v", "tier", app.Labels)
+	}
+}
+
+func TestApplyPatchesUnsupportedOp(t *testing.T) {
+	app := &App{Name: "app1"}
+	overlay := &Overlay{
+		Patches: []PatchOp{
+			{Op: "move", Path: "/name"},
+		},
+	}
+
+	if err := ApplyOverlay(app, overlay); err == nil {
+		t.Fatal("expected an error for an unsupported patch op, got nil")
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spec defines the kedge application definition, the compact,
+// higher level format that pkg/transform expands into plain Kubernetes
+// artifacts.
+package spec
+
+import (
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+	ext_v1beta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	networking_v1 "k8s.io/client-go/pkg/apis/networking/v1"
+)
+
+// App is the root of a kedge definition. It merges root level shorthands
+// (containers, services, ingresses, ...) with the full PodSpec/DeploymentSpec
+// so users can drop down to raw Kubernetes fields whenever the shorthand
+// isn't enough.
+type App struct {
+	Name   string            `json:"name,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations is propagated onto every generated resource's ObjectMeta,
+	// the same way Labels already is.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Controller selects what workload resource the PodSpec gets wrapped
+	// in: "deployment" (default), "pod", "statefulset", "daemonset", "job"
+	// or "cronjob".
+	Controller string `json:"controller,omitempty"`
+
+	// StatefulSet, DaemonSet, Job and CronJob hold the controller specific
+	// settings used when Controller selects them. Only the one matching
+	// Controller is read.
+	StatefulSet StatefulSetSpecMod `json:"statefulset,omitempty"`
+	DaemonSet   DaemonSetSpecMod   `json:"daemonset,omitempty"`
+	Job         JobSpecMod         `json:"job,omitempty"`
+	CronJob     CronJobSpecMod     `json:"cronjob,omitempty"`
+
+	Containers []ContainerSpecMod `json:"containers,omitempty"`
+	Volumes    []api_v1.Volume    `json:"volumes,omitempty"`
+
+	VolumeClaims []VolumeClaim    `json:"volumeClaims,omitempty"`
+	ConfigMaps   []ConfigMapMod   `json:"configMaps,omitempty"`
+	Secrets      []SecretMod      `json:"secrets,omitempty"`
+	Services     []ServiceSpecMod `json:"services,omitempty"`
+	Ingresses    []IngressSpecMod `json:"ingresses,omitempty"`
+
+	// NetworkPolicies are emitted as-is, alongside the ones derived from
+	// the allowFrom/allowTo shorthand on app.Services[].Ports and
+	// app.Containers.
+	NetworkPolicies []NetworkPolicyMod `json:"networkPolicies,omitempty"`
+
+	// DefaultDeny, when true, also emits a NetworkPolicy that denies all
+	// ingress and egress traffic to this app's pods not otherwise allowed
+	// by NetworkPolicies or the allowFrom/allowTo shorthand.
+	DefaultDeny bool `json:"defaultDeny,omitempty"`
+
+	// ExtraResources is a list of file names, relative to this file, of
+	// plain Kubernetes (or OpenShift) artifacts to apply/create alongside
+	// the ones generated from this App.
+	ExtraResources []string `json:"extraResources,omitempty"`
+
+	api_v1.PodSpec             `json:",inline"`
+	ext_v1beta1.DeploymentSpec `json:",inline"`
+}
+
+// ContainerSpecMod is a single entry of app.Containers. It wraps
+// api_v1.Container with kedge specific shorthands that get expanded away
+// before the container is fed into the generated PodSpec.
+type ContainerSpecMod struct {
+	api_v1.Container `json:",inline"`
+
+	// Health, if set, is used as both the ReadinessProbe and LivenessProbe.
+	// It is an error to also set either of those fields directly.
+	Health *api_v1.Probe `json:"health,omitempty"`
+
+	// EnvFrom shadows api_v1.Container's field of the same name; it is
+	// resolved against app.ConfigMaps/app.Secrets and expanded into plain
+	// Env entries, since kedge does not emit envFrom in its output.
+	EnvFrom []EnvFromMod `json:"envFrom,omitempty"`
+
+	// AllowTo is a shorthand for an egress NetworkPolicy rule: entries are
+	// "key=value" pod selector matches, plus an optional "port=N" entry
+	// restricting which destination port is allowed, e.g.
+	// ["app=db", "port=5432"].
+	AllowTo []string `json:"allowTo,omitempty"`
+}
+
+// EnvFromMod is a single entry of ContainerSpecMod.EnvFrom.
+type EnvFromMod struct {
+	ConfigMapRef *ConfigMapEnvSource `json:"configMapRef,omitempty"`
+	SecretRef    *SecretEnvSource    `json:"secretRef,omitempty"`
+}
+
+// ConfigMapEnvSource references a root level ConfigMap by name.
+type ConfigMapEnvSource struct {
+	Name string `json:"name"`
+}
+
+// SecretEnvSource references a root level Secret by name.
+type SecretEnvSource struct {
+	Name string `json:"name"`
+}
+
+// ConfigMapMod is a single entry of app.ConfigMaps.
+type ConfigMapMod struct {
+	Name string            `json:"name"`
+	Data map[string]string `json:"data,omitempty"`
+}
+
+// SecretMod is a single entry of app.Secrets.
+type SecretMod struct {
+	Name       string            `json:"name"`
+	Data       map[string][]byte `json:"data,omitempty"`
+	StringData map[string]string `json:"stringData,omitempty"`
+	Type       api_v1.SecretType `json:"type,omitempty"`
+}
+
+// VolumeClaim is a single entry of app.VolumeClaims. Size is a shorthand for
+// Resources.Requests[storage]; exactly one of the two must be set.
+type VolumeClaim struct {
+	Name string `json:"name"`
+	Size string `json:"size,omitempty"`
+
+	// PerReplica marks this claim to be wired as a StatefulSet
+	// volumeClaimTemplate instead of a standalone PVC. Only meaningful
+	// when app.Controller is "statefulset".
+	PerReplica bool `json:"perReplica,omitempty"`
+
+	api_v1.PersistentVolumeClaimSpec `json:",inline"`
+}
+
+// ServiceSpecMod is a single entry of app.Services.
+type ServiceSpecMod struct {
+	Name string `json:"name"`
+
+	api_v1.ServiceSpec `json:",inline"`
+
+	Ports []ServicePortMod `json:"ports,omitempty"`
+}
+
+// ServicePortMod wraps api_v1.ServicePort with an Endpoint shorthand that
+// generates an Ingress exposing this port.
+type ServicePortMod struct {
+	api_v1.ServicePort `json:",inline"`
+
+	// Endpoint is of the form "host[/path]". When set, kedge generates an
+	// Ingress routing that host/path to this service port.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// AllowFrom is a shorthand for an ingress NetworkPolicy rule allowing
+	// traffic to this port, as "key=value" pod selector matches, e.g.
+	// ["app=frontend"].
+	AllowFrom []string `json:"allowFrom,omitempty"`
+}
+
+// IngressSpecMod is a single entry of app.Ingresses.
+type IngressSpecMod struct {
+	Name string `json:"name"`
+
+	ext_v1beta1.IngressSpec `json:",inline"`
+}
+
+// NetworkPolicyMod is a single entry of app.NetworkPolicies.
+type NetworkPolicyMod struct {
+	Name string `json:"name"`
+
+	networking_v1.NetworkPolicySpec `json:",inline"`
+}
@@ -0,0 +1,301 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package statuscheck polls a Kubernetes cluster for the resources kedge
+// generated and reports once each one has reached a "Ready" state, the same
+// way `helm install --wait` does for a release.
+package statuscheck
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+	ext_v1beta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+// DefaultPollInterval is how often WaitForResources re-checks a resource's
+// status if the caller does not provide its own interval.
+const DefaultPollInterval = 2 * time.Second
+
+// readyFunc checks whether a single object, identified by namespace/name, is
+// ready. It returns a human readable reason when the object is not ready yet.
+type readyFunc func(client kubernetes.Interface, namespace, name string) (ready bool, reason string, err error)
+
+// WaitForResources polls the cluster until every one of objs reports ready,
+// or returns a per-resource diagnostic error once timeout elapses.
+//
+// Readiness is determined the same way Helm 3's kstatus checks do:
+//   - Deployment: observedGeneration caught up, updatedReplicas and
+//     availableReplicas both match spec.replicas, and no old ReplicaSets left.
+//   - Service: LoadBalancer services need status.loadBalancer.ingress
+//     populated; every other type is considered ready immediately.
+//   - PersistentVolumeClaim: status.phase == Bound.
+//   - Ingress: at least one status.loadBalancer.ingress entry.
+//   - Pod: phase Running and every container reporting ready.
+func WaitForResources(client kubernetes.Interface, namespace string, objs []runtime.Object, timeout, pollInterval time.Duration) error {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	pending := make(map[string]runtime.Object, len(objs))
+	for _, obj := range objs {
+		// Resource kinds we don't know how to wait on (Secret, ConfigMap)
+		// are considered ready as soon as they're applied.
+		if _, _, err := readyCheckFor(obj); err == nil {
+			pending[objKey(obj)] = obj
+		}
+	}
+
+	// reasons carries the last-seen not-ready reason for each resource still
+	// pending, so a timeout can report *why* each one never became ready
+	// instead of wait.PollImmediate's opaque "timed out" sentinel.
+	reasons := make(map[string]string, len(pending))
+
+	err := wait.PollImmediate(pollInterval, timeout, func() (bool, error) {
+		for key, obj := range pending {
+			check, accessor, err := readyCheckFor(obj)
+			if err != nil {
+				delete(pending, key)
+				delete(reasons, key)
+				continue
+			}
+
+			ready, reason, err := check(client, namespace, accessor.name)
+			if err != nil {
+				return false, errors.Wrapf(err, "checking readiness of %s %q", accessor.kind, accessor.name)
+			}
+			if !ready {
+				log.Debugf("waiting for %s %q: %s", accessor.kind, accessor.name, reason)
+				reasons[key] = fmt.Sprintf("%s %q: %s", accessor.kind, accessor.name, reason)
+				continue
+			}
+			delete(pending, key)
+			delete(reasons, key)
+		}
+		return len(pending) == 0, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return errors.Wrap(pendingResourcesError(reasons), "timed out waiting for resources to become ready")
+	}
+	return err
+}
+
+// pendingResourcesError composes the last-seen reasons for every resource
+// still pending at timeout into a single multi-resource error.
+func pendingResourcesError(reasons map[string]string) error {
+	keys := make([]string, 0, len(reasons))
+	for key := range reasons {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	msg := "no resources reported a reason"
+	for i, key := range keys {
+		if i == 0 {
+			msg = reasons[key]
+			continue
+		}
+		msg += "; " + reasons[key]
+	}
+	return errors.New(msg)
+}
+
+type objAccessor struct {
+	kind string
+	name string
+}
+
+func objKey(obj runtime.Object) string {
+	a, _ := metaAccessor(obj)
+	return a.kind + "/" + a.name
+}
+
+func metaAccessor(obj runtime.Object) (objAccessor, error) {
+	switch o := obj.(type) {
+	case *ext_v1beta1.Deployment:
+		return objAccessor{kind: "Deployment", name: o.Name}, nil
+	case *api_v1.Service:
+		return objAccessor{kind: "Service", name: o.Name}, nil
+	case *api_v1.PersistentVolumeClaim:
+		return objAccessor{kind: "PersistentVolumeClaim", name: o.Name}, nil
+	case *ext_v1beta1.Ingress:
+		return objAccessor{kind: "Ingress", name: o.Name}, nil
+	case *api_v1.Pod:
+		return objAccessor{kind: "Pod", name: o.Name}, nil
+	default:
+		return objAccessor{}, fmt.Errorf("no readiness check for %T", obj)
+	}
+}
+
+// readyCheckFor returns the readiness predicate and accessor to use for obj,
+// or an error if kedge does not wait on this kind of resource.
+func readyCheckFor(obj runtime.Object) (readyFunc, objAccessor, error) {
+	accessor, err := metaAccessor(obj)
+	if err != nil {
+		return nil, objAccessor{}, err
+	}
+
+	switch obj.(type) {
+	case *ext_v1beta1.Deployment:
+		return deploymentReady, accessor, nil
+	case *api_v1.Service:
+		return serviceReady, accessor, nil
+	case *api_v1.PersistentVolumeClaim:
+		return pvcReady, accessor, nil
+	case *ext_v1beta1.Ingress:
+		return ingressReady, accessor, nil
+	case *api_v1.Pod:
+		return podReady, accessor, nil
+	default:
+		return nil, objAccessor{}, fmt.Errorf("no readiness check for %T", obj)
+	}
+}
+
+func deploymentReady(client kubernetes.Interface, namespace, name string) (bool, string, error) {
+	d, err := client.ExtensionsV1beta1().Deployments(namespace).Get(name, v1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return false, "not created yet", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	if d.Status.ObservedGeneration < d.Generation {
+		return false, "waiting for observed generation to catch up", nil
+	}
+
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	if d.Status.UpdatedReplicas < replicas {
+		return false, fmt.Sprintf("%d of %d replicas updated", d.Status.UpdatedReplicas, replicas), nil
+	}
+	if d.Status.AvailableReplicas < replicas {
+		return false, fmt.Sprintf("%d of %d replicas available", d.Status.AvailableReplicas, replicas), nil
+	}
+
+	rss, err := client.ExtensionsV1beta1().ReplicaSets(namespace).List(v1.ListOptions{
+		LabelSelector: labelsSelector(d.Spec.Selector),
+	})
+	if err != nil {
+		return false, "", err
+	}
+	for _, rs := range rss.Items {
+		if rs.Labels["pod-template-hash"] != "" && rs.Spec.Replicas != nil && *rs.Spec.Replicas > 0 {
+			if owner := rs.Annotations["deployment.kubernetes.io/revision"]; owner != "" && owner != d.Annotations["deployment.kubernetes.io/revision"] {
+				return false, "old ReplicaSet still has replicas", nil
+			}
+		}
+	}
+
+	return true, "", nil
+}
+
+func serviceReady(client kubernetes.Interface, namespace, name string) (bool, string, error) {
+	s, err := client.CoreV1().Services(namespace).Get(name, v1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return false, "not created yet", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	if s.Spec.Type != api_v1.ServiceTypeLoadBalancer {
+		return true, "", nil
+	}
+	if len(s.Status.LoadBalancer.Ingress) == 0 {
+		return false, "waiting for load balancer ingress", nil
+	}
+	return true, "", nil
+}
+
+func pvcReady(client kubernetes.Interface, namespace, name string) (bool, string, error) {
+	p, err := client.CoreV1().PersistentVolumeClaims(namespace).Get(name, v1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return false, "not created yet", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	if p.Status.Phase != api_v1.ClaimBound {
+		return false, fmt.Sprintf("phase is %q, waiting for %q", p.Status.Phase, api_v1.ClaimBound), nil
+	}
+	return true, "", nil
+}
+
+func ingressReady(client kubernetes.Interface, namespace, name string) (bool, string, error) {
+	i, err := client.ExtensionsV1beta1().Ingresses(namespace).Get(name, v1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return false, "not created yet", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	if len(i.Status.LoadBalancer.Ingress) == 0 {
+		return false, "waiting for load balancer ingress", nil
+	}
+	return true, "", nil
+}
+
+func podReady(client kubernetes.Interface, namespace, name string) (bool, string, error) {
+	p, err := client.CoreV1().Pods(namespace).Get(name, v1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return false, "not created yet", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	if p.Status.Phase != api_v1.PodRunning {
+		return false, fmt.Sprintf("phase is %q, waiting for %q", p.Status.Phase, api_v1.PodRunning), nil
+	}
+	for _, cs := range p.Status.ContainerStatuses {
+		if !cs.Ready {
+			return false, fmt.Sprintf("container %q not ready", cs.Name), nil
+		}
+	}
+	return true, "", nil
+}
+
+func labelsSelector(selector *v1.LabelSelector) string {
+	if selector == nil {
+		return ""
+	}
+	sel := make([]string, 0, len(selector.MatchLabels))
+	for k, val := range selector.MatchLabels {
+		sel = append(sel, k+"="+val)
+	}
+	s := ""
+	for i, kv := range sel {
+		if i > 0 {
+			s += ","
+		}
+		s += kv
+	}
+	return s
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statuscheck
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+	ext_v1beta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+func int32ptr(i int32) *int32 { return &i }
+
+func TestDeploymentReadyNotCreated(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	ready, _, err := deploymentReady(client, "default", "web")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ready {
+		t.Fatal("expected a Deployment that doesn't exist yet to be not ready")
+	}
+}
+
+func TestDeploymentReadyWaitsForAvailableReplicas(t *testing.T) {
+	deployment := &ext_v1beta1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Generation: 1},
+		Spec:       ext_v1beta1.DeploymentSpec{Replicas: int32ptr(2)},
+		Status: ext_v1beta1.DeploymentStatus{
+			ObservedGeneration: 1,
+			UpdatedReplicas:    2,
+			AvailableReplicas:  1,
+		},
+	}
+	client := fake.NewSimpleClientset(deployment)
+
+	ready, reason, err := deploymentReady(client, "default", "web")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ready {
+		t.Fatal("expected not ready while availableReplicas lags spec.replicas")
+	}
+	if reason == "" {
+		t.Error("expected a non-empty reason")
+	}
+}
+
+func TestDeploymentReadyOldReplicaSetStillScaled(t *testing.T) {
+	deployment := &ext_v1beta1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "web", Namespace: "default", Generation: 1,
+			Annotations: map[string]string{"deployment.kubernetes.io/revision": "2"},
+		},
+		Spec: ext_v1beta1.DeploymentSpec{
+			Replicas: int32ptr(1),
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+		Status: ext_v1beta1.DeploymentStatus{
+			ObservedGeneration: 1,
+			UpdatedReplicas:    1,
+			AvailableReplicas:  1,
+		},
+	}
+	oldReplicaSet := &ext_v1beta1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "web-old", Namespace: "default",
+			Labels:      map[string]string{"app": "web", "pod-template-hash": "abc123"},
+			Annotations: map[string]string{"deployment.kubernetes.io/revision": "1"},
+		},
+		Spec: ext_v1beta1.ReplicaSetSpec{Replicas: int32ptr(1)},
+	}
+	client := fake.NewSimpleClientset(deployment, oldReplicaSet)
+
+	ready, reason, err := deploymentReady(client, "default", "web")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ready {
+		t.Fatal("expected not ready while an old, differently-revisioned ReplicaSet still has replicas")
+	}
+	if reason == "" {
+		t.Error("expected a non-empty reason")
+	}
+}
+
+func TestServiceReadyClusterIPIsImmediatelyReady(t *testing.T) {
+	svc := &api_v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       api_v1.ServiceSpec{Type: api_v1.ServiceTypeClusterIP},
+	}
+	client := fake.NewSimpleClientset(svc)
+
+	ready, _, err := serviceReady(client, "default", "web")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ready {
+		t.Fatal("expected a ClusterIP Service to be ready as soon as it's created")
+	}
+}
+
+func TestServiceReadyLoadBalancerWaitsForIngress(t *testing.T) {
+	svc := &api_v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       api_v1.ServiceSpec{Type: api_v1.ServiceTypeLoadBalancer},
+	}
+	client := fake.NewSimpleClientset(svc)
+
+	ready, reason, err := serviceReady(client, "default", "web")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ready {
+		t.Fatal("expected a LoadBalancer Service with no ingress yet to be not ready")
+	}
+	if reason == "" {
+		t.Error("expected a non-empty reason")
+	}
+}
+
+func TestPVCReadyWaitsForBound(t *testing.T) {
+	pvc := &api_v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"},
+		Status:     api_v1.PersistentVolumeClaimStatus{Phase: api_v1.ClaimPending},
+	}
+	client := fake.NewSimpleClientset(pvc)
+
+	ready, reason, err := pvcReady(client, "default", "data")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ready {
+		t.Fatal("expected a pending PVC to be not ready")
+	}
+	if reason == "" {
+		t.Error("expected a non-empty reason")
+	}
+}
+
+func TestPodReadyWaitsForContainerReady(t *testing.T) {
+	pod := &api_v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Status: api_v1.PodStatus{
+			Phase:             api_v1.PodRunning,
+			ContainerStatuses: []api_v1.ContainerStatus{{Name: "web", Ready: false}},
+		},
+	}
+	client := fake.NewSimpleClientset(pod)
+
+	ready, reason, err := podReady(client, "default", "web")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ready {
+		t.Fatal("expected a Running Pod with a not-ready container to be not ready")
+	}
+	if reason == "" {
+		t.Error("expected a non-empty reason")
+	}
+}
+
+func TestWaitForResourcesReadyImmediately(t *testing.T) {
+	svc := &api_v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       api_v1.ServiceSpec{Type: api_v1.ServiceTypeClusterIP},
+	}
+	client := fake.NewSimpleClientset(svc)
+
+	err := WaitForResources(client, "default", []runtime.Object{svc}, time.Second, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWaitForResourcesTimeoutReportsReason(t *testing.T) {
+	svc := &api_v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       api_v1.ServiceSpec{Type: api_v1.ServiceTypeLoadBalancer},
+	}
+	client := fake.NewSimpleClientset(svc)
+
+	err := WaitForResources(client, "default", []runtime.Object{svc}, 30*time.Millisecond, 10*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected a timeout error when the Service never becomes ready")
+	}
+}
+
+func TestWaitForResourcesSkipsUnknownKinds(t *testing.T) {
+	cm := &api_v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cfg", Namespace: "default"}}
+	client := fake.NewSimpleClientset(cm)
+
+	err := WaitForResources(client, "default", []runtime.Object{cm}, time.Second, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected resource kinds with no readiness check to be considered ready immediately, got: %v", err)
+	}
+}
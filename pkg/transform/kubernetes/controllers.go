@@ -0,0 +1,289 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"fmt"
+
+	"github.com/kedgeproject/kedge/pkg/spec"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+	appsv1beta1 "k8s.io/client-go/pkg/apis/apps/v1beta1"
+	batchv1 "k8s.io/client-go/pkg/apis/batch/v1"
+	batchv2alpha1 "k8s.io/client-go/pkg/apis/batch/v2alpha1"
+	ext_v1beta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+// Supported values for app.Controller. ControllerPod is declared in
+// kubernetes.go alongside createPod.
+const (
+	ControllerDeployment  = "deployment"
+	ControllerStatefulSet = "statefulset"
+	ControllerDaemonSet   = "daemonset"
+	ControllerJob         = "job"
+	ControllerCronJob     = "cronjob"
+)
+
+// controllerFactory builds the single workload resource for a given
+// app.Controller value. The deployment factory returns a nil object and nil
+// error when there isn't enough data to build one; the statefulset,
+// daemonset, job and cronjob factories return an error in that case.
+type controllerFactory func(app *spec.App) (runtime.Object, error)
+
+// controllers is the registry CreateK8sObjects dispatches app.Controller
+// through. New controllers are added here, not by editing CreateK8sObjects.
+var controllers = map[string]controllerFactory{
+	"":                    deploymentController,
+	ControllerDeployment:  deploymentController,
+	ControllerPod:         podController,
+	ControllerStatefulSet: statefulSetController,
+	ControllerDaemonSet:   daemonSetController,
+	ControllerJob:         jobController,
+	ControllerCronJob:     cronJobController,
+}
+
+// Each *Controller below adapts a typed `func(*spec.App) (*T, error)`
+// factory to controllerFactory, taking care to return a true nil
+// runtime.Object (not a non-nil interface wrapping a nil pointer) when the
+// factory found nothing to build.
+
+func deploymentController(app *spec.App) (runtime.Object, error) {
+	d, err := createDeployment(app)
+	if err != nil || d == nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func podController(app *spec.App) (runtime.Object, error) {
+	p, err := createPod(app)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func statefulSetController(app *spec.App) (runtime.Object, error) {
+	s, err := createStatefulSet(app)
+	if err != nil || s == nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func daemonSetController(app *spec.App) (runtime.Object, error) {
+	d, err := createDaemonSet(app)
+	if err != nil || d == nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func jobController(app *spec.App) (runtime.Object, error) {
+	j, err := createJob(app)
+	if err != nil || j == nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func cronJobController(app *spec.App) (runtime.Object, error) {
+	c, err := createCronJob(app)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// createControllerObject dispatches app.Controller to the matching
+// controllerFactory.
+func createControllerObject(app *spec.App) (runtime.Object, error) {
+	factory, ok := controllers[app.Controller]
+	if !ok {
+		return nil, fmt.Errorf("app %q: unknown controller %q", app.Name, app.Controller)
+	}
+	return factory(app)
+}
+
+// headlessServiceDefined reports whether app.Services contains a headless
+// (ClusterIP: None) Service, required for a StatefulSet's network identity.
+func headlessServiceDefined(app *spec.App) bool {
+	for _, s := range app.Services {
+		if s.ClusterIP == api_v1.ClusterIPNone {
+			return true
+		}
+	}
+	return false
+}
+
+// createStatefulSet creates a StatefulSet resource when app.Controller is
+// "statefulset". Root level VolumeClaims marked PerReplica are wired in as
+// volumeClaimTemplates instead of standalone PVCs.
+func createStatefulSet(app *spec.App) (*appsv1beta1.StatefulSet, error) {
+	if app.Controller != ControllerStatefulSet {
+		return nil, nil
+	}
+
+	if !headlessServiceDefined(app) {
+		return nil, fmt.Errorf("app %q: controller %q requires a headless Service (clusterIP: None)", app.Name, ControllerStatefulSet)
+	}
+
+	if !podSpecProvided(app) {
+		return nil, fmt.Errorf("app %q: controller %q has no containers, not enough data to create a StatefulSet", app.Name, ControllerStatefulSet)
+	}
+
+	podSpec, err := mergedPodSpec(app)
+	if err != nil {
+		return nil, err
+	}
+
+	statefulSetSpec := app.StatefulSet.StatefulSetSpec
+	statefulSetSpec.Template.Spec = podSpec
+	statefulSetSpec.Template.ObjectMeta.Name = app.Name
+	statefulSetSpec.Template.ObjectMeta.Labels = app.Labels
+	if statefulSetSpec.ServiceName == "" {
+		statefulSetSpec.ServiceName = app.Name
+	}
+
+	for _, v := range app.VolumeClaims {
+		if !v.PerReplica {
+			continue
+		}
+		pvc, err := createPVC(v, app.Labels)
+		if err != nil {
+			return nil, err
+		}
+		statefulSetSpec.VolumeClaimTemplates = append(statefulSetSpec.VolumeClaimTemplates, *pvc)
+	}
+
+	return &appsv1beta1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        app.Name,
+			Labels:      app.Labels,
+			Annotations: app.Annotations,
+		},
+		Spec: statefulSetSpec,
+	}, nil
+}
+
+// createDaemonSet creates a DaemonSet resource when app.Controller is
+// "daemonset". DaemonSets run one Pod per node, so app.Replicas is rejected.
+func createDaemonSet(app *spec.App) (*ext_v1beta1.DaemonSet, error) {
+	if app.Controller != ControllerDaemonSet {
+		return nil, nil
+	}
+
+	if app.DeploymentSpec.Replicas != nil {
+		return nil, fmt.Errorf("app %q: controller %q does not support replicas, it runs one Pod per node", app.Name, ControllerDaemonSet)
+	}
+
+	if !podSpecProvided(app) {
+		return nil, fmt.Errorf("app %q: controller %q has no containers, not enough data to create a DaemonSet", app.Name, ControllerDaemonSet)
+	}
+
+	podSpec, err := mergedPodSpec(app)
+	if err != nil {
+		return nil, err
+	}
+
+	daemonSetSpec := app.DaemonSet.DaemonSetSpec
+	daemonSetSpec.Template.Spec = podSpec
+	daemonSetSpec.Template.ObjectMeta.Name = app.Name
+	daemonSetSpec.Template.ObjectMeta.Labels = app.Labels
+
+	return &ext_v1beta1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        app.Name,
+			Labels:      app.Labels,
+			Annotations: app.Annotations,
+		},
+		Spec: daemonSetSpec,
+	}, nil
+}
+
+// createJob creates a Job resource when app.Controller is "job".
+func createJob(app *spec.App) (*batchv1.Job, error) {
+	if app.Controller != ControllerJob {
+		return nil, nil
+	}
+
+	if !podSpecProvided(app) {
+		return nil, fmt.Errorf("app %q: controller %q has no containers, not enough data to create a Job", app.Name, ControllerJob)
+	}
+
+	podSpec, err := mergedPodSpec(app)
+	if err != nil {
+		return nil, err
+	}
+	if podSpec.RestartPolicy == api_v1.RestartPolicyAlways {
+		return nil, fmt.Errorf("app %q: controller %q does not support restartPolicy Always", app.Name, ControllerJob)
+	}
+
+	jobSpec := app.Job.JobSpec
+	jobSpec.Template.Spec = podSpec
+	jobSpec.Template.ObjectMeta.Name = app.Name
+	jobSpec.Template.ObjectMeta.Labels = app.Labels
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        app.Name,
+			Labels:      app.Labels,
+			Annotations: app.Annotations,
+		},
+		Spec: jobSpec,
+	}, nil
+}
+
+// createCronJob creates a CronJob resource when app.Controller is
+// "cronjob". app.CronJob.Schedule is required.
+func createCronJob(app *spec.App) (*batchv2alpha1.CronJob, error) {
+	if app.Controller != ControllerCronJob {
+		return nil, nil
+	}
+
+	if app.CronJob.Schedule == "" {
+		return nil, fmt.Errorf("app %q: controller %q requires cronjob.schedule to be set", app.Name, ControllerCronJob)
+	}
+
+	if !podSpecProvided(app) {
+		return nil, fmt.Errorf("app %q: controller %q has no containers, not enough data to create a CronJob", app.Name, ControllerCronJob)
+	}
+
+	podSpec, err := mergedPodSpec(app)
+	if err != nil {
+		return nil, err
+	}
+	if podSpec.RestartPolicy == api_v1.RestartPolicyAlways {
+		return nil, fmt.Errorf("app %q: controller %q does not support restartPolicy Always", app.Name, ControllerCronJob)
+	}
+
+	cronJobSpec := app.CronJob.CronJobSpec
+	cronJobSpec.JobTemplate.Spec.Template.Spec = podSpec
+	cronJobSpec.JobTemplate.Spec.Template.ObjectMeta.Name = app.Name
+	cronJobSpec.JobTemplate.Spec.Template.ObjectMeta.Labels = app.Labels
+
+	return &batchv2alpha1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        app.Name,
+			Labels:      app.Labels,
+			Annotations: app.Annotations,
+		},
+		Spec: cronJobSpec,
+	}, nil
+}
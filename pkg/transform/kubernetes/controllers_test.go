@@ -0,0 +1,133 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/kedgeproject/kedge/pkg/spec"
+
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+)
+
+func podSpecApp(name, controller string) *spec.App {
+	app := &spec.App{Name: name, Controller: controller}
+	app.PodSpec = api_v1.PodSpec{Containers: []api_v1.Container{{Name: "web", Image: "nginx"}}}
+	return app
+}
+
+func TestCreateStatefulSetRequiresHeadlessService(t *testing.T) {
+	app := podSpecApp("app1", ControllerStatefulSet)
+
+	if _, err := createStatefulSet(app); err == nil {
+		t.Fatal("expected an error when no headless Service is defined")
+	}
+}
+
+func TestCreateStatefulSetRequiresPodSpec(t *testing.T) {
+	app := &spec.App{Name: "app1", Controller: ControllerStatefulSet}
+	app.Services = []spec.ServiceSpecMod{{Name: "app1", ServiceSpec: api_v1.ServiceSpec{ClusterIP: api_v1.ClusterIPNone}}}
+
+	if _, err := createStatefulSet(app); err == nil {
+		t.Fatal("expected an error when no PodSpec is provided")
+	}
+}
+
+func TestCreateStatefulSetWiresPerReplicaVolumeClaims(t *testing.T) {
+	app := podSpecApp("app1", ControllerStatefulSet)
+	app.Services = []spec.ServiceSpecMod{{Name: "app1", ServiceSpec: api_v1.ServiceSpec{ClusterIP: api_v1.ClusterIPNone}}}
+	app.VolumeClaims = []spec.VolumeClaim{{Name: "data", Size: "1Gi", PerReplica: true}}
+
+	ss, err := createStatefulSet(app)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ss == nil {
+		t.Fatal("expected a non-nil StatefulSet")
+	}
+	if len(ss.Spec.VolumeClaimTemplates) != 1 || ss.Spec.VolumeClaimTemplates[0].Name != "data" {
+		t.Errorf("expected the PerReplica claim to become a volumeClaimTemplate, got %+v", ss.Spec.VolumeClaimTemplates)
+	}
+	if ss.Spec.ServiceName != "app1" {
+		t.Errorf("expected ServiceName to default to the app name, got %q", ss.Spec.ServiceName)
+	}
+}
+
+func TestCreateDaemonSetRejectsReplicas(t *testing.T) {
+	replicas := int32(3)
+	app := podSpecApp("app1", ControllerDaemonSet)
+	app.DeploymentSpec.Replicas = &replicas
+
+	if _, err := createDaemonSet(app); err == nil {
+		t.Fatal("expected an error when replicas is set on a DaemonSet controller")
+	}
+}
+
+func TestCreateDaemonSetRequiresPodSpec(t *testing.T) {
+	app := &spec.App{Name: "app1", Controller: ControllerDaemonSet}
+
+	if _, err := createDaemonSet(app); err == nil {
+		t.Fatal("expected an error when no PodSpec is provided")
+	}
+}
+
+func TestCreateJobRejectsRestartPolicyAlways(t *testing.T) {
+	app := podSpecApp("app1", ControllerJob)
+	app.PodSpec.RestartPolicy = api_v1.RestartPolicyAlways
+
+	if _, err := createJob(app); err == nil {
+		t.Fatal("expected an error when restartPolicy is Always on a Job controller")
+	}
+}
+
+func TestCreateCronJobRequiresSchedule(t *testing.T) {
+	app := podSpecApp("app1", ControllerCronJob)
+
+	if _, err := createCronJob(app); err == nil {
+		t.Fatal("expected an error when cronjob.schedule is not set")
+	}
+}
+
+func TestCreateCronJobRejectsRestartPolicyAlways(t *testing.T) {
+	app := podSpecApp("app1", ControllerCronJob)
+	app.CronJob.Schedule = "* * * * *"
+	app.PodSpec.RestartPolicy = api_v1.RestartPolicyAlways
+
+	if _, err := createCronJob(app); err == nil {
+		t.Fatal("expected an error when restartPolicy is Always on a CronJob controller")
+	}
+}
+
+func TestCreateControllerObjectDispatchesByController(t *testing.T) {
+	app := podSpecApp("app1", ControllerPod)
+
+	obj, err := createControllerObject(app)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := obj.(*api_v1.Pod); !ok {
+		t.Fatalf("expected a *api_v1.Pod, got %T", obj)
+	}
+}
+
+func TestCreateControllerObjectUnknownController(t *testing.T) {
+	app := &spec.App{Name: "app1", Controller: "bogus"}
+
+	if _, err := createControllerObject(app); err == nil {
+		t.Fatal("expected an error for an unknown controller")
+	}
+}
@@ -51,8 +51,9 @@ func createIngresses(app *spec.App) ([]runtime.Object, error) {
 	for _, i := range app.Ingresses {
 		ing := &ext_v1beta1.Ingress{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:   i.Name,
-				Labels: app.Labels,
+				Name:        i.Name,
+				Labels:      app.Labels,
+				Annotations: app.Annotations,
 			},
 			Spec: i.IngressSpec,
 		}
@@ -66,8 +67,9 @@ func createServices(app *spec.App) ([]runtime.Object, error) {
 	for _, s := range app.Services {
 		svc := &api_v1.Service{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:   s.Name,
-				Labels: app.Labels,
+				Name:        s.Name,
+				Labels:      app.Labels,
+				Annotations: app.Annotations,
 			},
 			Spec: s.ServiceSpec,
 		}
@@ -99,8 +101,9 @@ func createServices(app *spec.App) ([]runtime.Object, error) {
 				ingressName := s.Name + "-" + strconv.FormatInt(int64(port.Port), 10)
 				endpointIngress := &ext_v1beta1.Ingress{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:   ingressName,
-						Labels: app.Labels,
+						Name:        ingressName,
+						Labels:      app.Labels,
+						Annotations: app.Annotations,
 					},
 					Spec: ext_v1beta1.IngressSpec{
 						Rules: []ext_v1beta1.IngressRule{
@@ -133,30 +136,28 @@ func createServices(app *spec.App) ([]runtime.Object, error) {
 }
 
 // Creates a Deployment Kubernetes resource. The returned Deployment resource
-// will be nil if it could not be generated due to insufficient input data.
+// will be nil if it could not be generated due to insufficient input data,
+// or if app.Controller requests a different workload resource.
 func createDeployment(app *spec.App) (*ext_v1beta1.Deployment, error) {
 
+	// Other controllers are handled by their own factories instead.
+	if app.Controller != "" && app.Controller != ControllerDeployment {
+		return nil, nil
+	}
+
 	// We need to error out if both, app.PodSpec and app.DeploymentSpec are empty
 	if reflect.DeepEqual(app.PodSpec, api_v1.PodSpec{}) && reflect.DeepEqual(app.DeploymentSpec, ext_v1beta1.DeploymentSpec{}) {
 		log.Debug("Both, app.PodSpec and app.DeploymentSpec are empty, not enough data to create a deployment.")
 		return nil, nil
 	}
 
-	// We are merging whole DeploymentSpec with PodSpec.
-	// This means that someone could specify containers in template.spec and also in top level PodSpec.
-	// This stupid check is supposed to make sure that only one of them set.
-	// TODO: merge DeploymentSpec.Template.Spec and top level PodSpec
-	if !(reflect.DeepEqual(app.DeploymentSpec.Template.Spec, api_v1.PodSpec{}) || reflect.DeepEqual(app.PodSpec, api_v1.PodSpec{})) {
-		return nil, fmt.Errorf("Pod can't be specfied in two places. Use top level PodSpec or template.spec (DeploymentSpec.Template.Spec) not both")
-	}
-
 	deploymentSpec := app.DeploymentSpec
 
-	// top level PodSpec is not empty, use it for deployment template
-	// we already know that if app.PodSpec is not empty app.DeploymentSpec.Template.Spec is empty
-	if !reflect.DeepEqual(app.PodSpec, api_v1.PodSpec{}) {
-		deploymentSpec.Template.Spec = app.PodSpec
+	podSpec, err := mergedPodSpec(app)
+	if err != nil {
+		return nil, err
 	}
+	deploymentSpec.Template.Spec = podSpec
 
 	// TODO: check if this wasn't set by user, in that case we shouldn't ovewrite it
 	deploymentSpec.Template.ObjectMeta.Name = app.Name
@@ -166,8 +167,9 @@ func createDeployment(app *spec.App) (*ext_v1beta1.Deployment, error) {
 
 	deployment := ext_v1beta1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   app.Name,
-			Labels: app.Labels,
+			Name:        app.Name,
+			Labels:      app.Labels,
+			Annotations: app.Annotations,
 		},
 		Spec: deploymentSpec,
 	}
@@ -175,6 +177,71 @@ func createDeployment(app *spec.App) (*ext_v1beta1.Deployment, error) {
 	return &deployment, nil
 }
 
+// mergedPodSpec merges app.PodSpec and app.DeploymentSpec.Template.Spec, the
+// two places a user can describe the Pod template from. It is an error for
+// both to be set, since merging them field by field would be ambiguous.
+func mergedPodSpec(app *spec.App) (api_v1.PodSpec, error) {
+	// We are merging whole DeploymentSpec with PodSpec.
+	// This means that someone could specify containers in template.spec and also in top level PodSpec.
+	// This stupid check is supposed to make sure that only one of them set.
+	// TODO: merge DeploymentSpec.Template.Spec and top level PodSpec
+	if !(reflect.DeepEqual(app.DeploymentSpec.Template.Spec, api_v1.PodSpec{}) || reflect.DeepEqual(app.PodSpec, api_v1.PodSpec{})) {
+		return api_v1.PodSpec{}, fmt.Errorf("Pod can't be specfied in two places. Use top level PodSpec or template.spec (DeploymentSpec.Template.Spec) not both")
+	}
+
+	if !reflect.DeepEqual(app.PodSpec, api_v1.PodSpec{}) {
+		return app.PodSpec, nil
+	}
+	return app.DeploymentSpec.Template.Spec, nil
+}
+
+// podSpecProvided reports whether app carries enough data for mergedPodSpec
+// to build a non-empty PodSpec. The statefulset, daemonset, job and cronjob
+// factories check this before calling mergedPodSpec and return an error when
+// it is false, instead of handing the server a workload with a completely
+// empty PodSpec.
+func podSpecProvided(app *spec.App) bool {
+	return !reflect.DeepEqual(app.PodSpec, api_v1.PodSpec{}) ||
+		!reflect.DeepEqual(app.DeploymentSpec.Template.Spec, api_v1.PodSpec{})
+}
+
+// ControllerPod is the app.Controller value that makes CreateK8sObjects emit
+// a bare Pod instead of a Deployment, for specs meant to be consumed by
+// `podman play kube`.
+const ControllerPod = "pod"
+
+// createPod creates a bare v1.Pod resource when app.Controller == "pod".
+// The returned Pod will be nil if this controller wasn't requested.
+// Deployment-only fields (rolling update strategy, replicas > 1) are
+// rejected since they have no equivalent on a standalone Pod.
+func createPod(app *spec.App) (*api_v1.Pod, error) {
+	if app.Controller != ControllerPod {
+		return nil, nil
+	}
+
+	if !reflect.DeepEqual(app.DeploymentSpec.Strategy, ext_v1beta1.DeploymentStrategy{}) {
+		return nil, fmt.Errorf("app.Controller %q does not support a deployment strategy", ControllerPod)
+	}
+	if app.DeploymentSpec.Replicas != nil && *app.DeploymentSpec.Replicas > 1 {
+		return nil, fmt.Errorf("app.Controller %q does not support more than 1 replica, got %d", ControllerPod, *app.DeploymentSpec.Replicas)
+	}
+
+	podSpec, err := mergedPodSpec(app)
+	if err != nil {
+		return nil, err
+	}
+
+	pod := &api_v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        app.Name,
+			Labels:      app.Labels,
+			Annotations: app.Annotations,
+		},
+		Spec: podSpec,
+	}
+	return pod, nil
+}
+
 // search through all the persistent volumes defined in the root level
 func isPVCDefined(app *spec.App, name string) bool {
 	for _, v := range app.VolumeClaims {
@@ -185,6 +252,36 @@ func isPVCDefined(app *spec.App, name string) bool {
 	return false
 }
 
+// isPerReplicaPVC reports whether the named root level persistent volume is
+// marked PerReplica, i.e. it becomes a StatefulSet volumeClaimTemplate
+// instead of a standalone PVC. PerReplica only makes sense for the
+// statefulset controller, which is validated by requirePerReplicaSupported.
+func isPerReplicaPVC(app *spec.App, name string) bool {
+	for _, v := range app.VolumeClaims {
+		if v.Name == name {
+			return v.PerReplica
+		}
+	}
+	return false
+}
+
+// requirePerReplicaSupported rejects any VolumeClaim marked PerReplica when
+// app.Controller isn't "statefulset": a StatefulSet is the only controller
+// that turns a PerReplica claim into a volumeClaimTemplate, so for every
+// other controller it would otherwise be silently dropped, leaving an
+// invalid PodSpec that only fails once applied to a cluster.
+func requirePerReplicaSupported(app *spec.App) error {
+	if app.Controller == ControllerStatefulSet {
+		return nil
+	}
+	for _, v := range app.VolumeClaims {
+		if v.PerReplica {
+			return fmt.Errorf("app %q: volume claim %q is marked perReplica, which requires controller: %s (got %q)", app.Name, v.Name, ControllerStatefulSet, app.Controller)
+		}
+	}
+	return nil
+}
+
 // create PVC reading the root level persistent volume field
 func createPVC(v spec.VolumeClaim, labels map[string]string) (*api_v1.PersistentVolumeClaim, error) {
 	// check for conditions where user has given both conflicting fields
@@ -243,6 +340,12 @@ func isVolumeDefined(app *spec.App, name string) bool {
 func populateVolumes(app *spec.App) error {
 	for cn, c := range app.PodSpec.Containers {
 		for vn, vm := range c.VolumeMounts {
+			// volumeClaimTemplates are matched to volumeMounts by name
+			// directly by the StatefulSet controller, no pod level volume
+			// entry is needed or wanted for them
+			if isPerReplicaPVC(app, vm.Name) {
+				continue
+			}
 			if isPVCDefined(app, vm.Name) && !isVolumeDefined(app, vm.Name) {
 				app.Volumes = append(app.Volumes, api_v1.Volume{
 					Name: vm.Name,
@@ -386,8 +489,9 @@ func createSecrets(app *spec.App) ([]runtime.Object, error) {
 	for _, s := range app.Secrets {
 		secret := &api_v1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:   s.Name,
-				Labels: app.Labels,
+				Name:        s.Name,
+				Labels:      app.Labels,
+				Annotations: app.Annotations,
 			},
 			Data:       s.Data,
 			StringData: s.StringData,
@@ -409,6 +513,10 @@ func CreateK8sObjects(app *spec.App) ([]runtime.Object, []string, error) {
 		app.Labels = getLabels(app)
 	}
 
+	if err := requirePerReplicaSupported(app); err != nil {
+		return nil, nil, err
+	}
+
 	svcs, err := createServices(app)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "Unable to create Kubernetes Service")
@@ -424,6 +532,11 @@ func CreateK8sObjects(app *spec.App) ([]runtime.Object, []string, error) {
 		return nil, nil, errors.Wrap(err, "Unable to create Kubernetes Secrets")
 	}
 
+	netpols, err := createNetworkPolicies(app)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Unable to create Kubernetes NetworkPolicies")
+	}
+
 	// withdraw the health and populate actual pod spec
 	if err := populateContainerHealth(app); err != nil {
 		return nil, nil, errors.Wrapf(err, "app %q", app.Name)
@@ -436,8 +549,13 @@ func CreateK8sObjects(app *spec.App) ([]runtime.Object, []string, error) {
 	}
 
 	// create pvc for each root level persistent volume
+	// volume claims marked PerReplica are wired in as volumeClaimTemplates
+	// by the statefulset controller instead, not as standalone PVCs
 	var pvcs []runtime.Object
 	for _, v := range app.VolumeClaims {
+		if v.PerReplica {
+			continue
+		}
 		pvc, err := createPVC(v, app.Labels)
 		if err != nil {
 			return nil, nil, errors.Wrapf(err, "app %q", app.Name)
@@ -452,8 +570,9 @@ func CreateK8sObjects(app *spec.App) ([]runtime.Object, []string, error) {
 	for _, cd := range app.ConfigMaps {
 		cm := &api_v1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:   cd.Name,
-				Labels: app.Labels,
+				Name:        cd.Name,
+				Labels:      app.Labels,
+				Annotations: app.Annotations,
 			},
 			Data: cd.Data,
 		}
@@ -461,16 +580,16 @@ func CreateK8sObjects(app *spec.App) ([]runtime.Object, []string, error) {
 		configMap = append(configMap, cm)
 	}
 
-	deployment, err := createDeployment(app)
+	// createControllerObject dispatches app.Controller (deployment, pod,
+	// statefulset, daemonset, job or cronjob) through the controller
+	// registry to build the single workload resource for this app.
+	controller, err := createControllerObject(app)
 	if err != nil {
 		return nil, nil, errors.Wrapf(err, "app %q", app.Name)
 	}
-
-	// deployment will be nil if no deployment is generated and no error occurs,
-	// so we only need to append this when a legit deployment resource is returned
-	if deployment != nil {
-		objects = append(objects, deployment)
-		log.Debugf("app: %s, deployment: %s\n", app.Name, spew.Sprint(deployment))
+	if controller != nil {
+		objects = append(objects, controller)
+		log.Debugf("app: %s, controller: %s\n", app.Name, spew.Sprint(controller))
 	}
 	objects = append(objects, configMap...)
 	log.Debugf("app: %s, configMap: %s\n", app.Name, spew.Sprint(configMap))
@@ -487,6 +606,9 @@ func CreateK8sObjects(app *spec.App) ([]runtime.Object, []string, error) {
 	objects = append(objects, secs...)
 	log.Debugf("app: %s, secret: %s\n", app.Name, spew.Sprint(secs))
 
+	objects = append(objects, netpols...)
+	log.Debugf("app: %s, networkPolicy: %s\n", app.Name, spew.Sprint(netpols))
+
 	return objects, app.ExtraResources, nil
 }
 
@@ -0,0 +1,85 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/kedgeproject/kedge/pkg/spec"
+
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+	ext_v1beta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+func TestCreatePodNotRequested(t *testing.T) {
+	app := &spec.App{Name: "app1"}
+
+	pod, err := createPod(app)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pod != nil {
+		t.Fatalf("expected a nil Pod when app.Controller != %q, got %+v", ControllerPod, pod)
+	}
+}
+
+func TestCreatePodBuildsFromPodSpec(t *testing.T) {
+	app := &spec.App{
+		Name:       "app1",
+		Controller: ControllerPod,
+	}
+	app.PodSpec = api_v1.PodSpec{Containers: []api_v1.Container{{Name: "web", Image: "nginx"}}}
+
+	pod, err := createPod(app)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pod == nil {
+		t.Fatal("expected a non-nil Pod")
+	}
+	if pod.Name != "app1" {
+		t.Errorf("expected pod name %q, got %q", "app1", pod.Name)
+	}
+	if len(pod.Spec.Containers) != 1 || pod.Spec.Containers[0].Name != "web" {
+		t.Errorf("expected the app's PodSpec to be used, got %+v", pod.Spec)
+	}
+}
+
+func TestCreatePodRejectsDeploymentStrategy(t *testing.T) {
+	app := &spec.App{
+		Name:       "app1",
+		Controller: ControllerPod,
+	}
+	app.DeploymentSpec.Strategy = ext_v1beta1.DeploymentStrategy{Type: ext_v1beta1.RollingUpdateDeploymentStrategyType}
+
+	if _, err := createPod(app); err == nil {
+		t.Fatal("expected an error when a deployment strategy is set on a pod controller")
+	}
+}
+
+func TestCreatePodRejectsMultipleReplicas(t *testing.T) {
+	replicas := int32(2)
+	app := &spec.App{
+		Name:       "app1",
+		Controller: ControllerPod,
+	}
+	app.DeploymentSpec.Replicas = &replicas
+
+	if _, err := createPod(app); err == nil {
+		t.Fatal("expected an error when replicas > 1 is set on a pod controller")
+	}
+}
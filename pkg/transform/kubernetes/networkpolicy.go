@@ -0,0 +1,201 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/kedgeproject/kedge/pkg/spec"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+	networking_v1 "k8s.io/client-go/pkg/apis/networking/v1"
+)
+
+// createNetworkPolicies builds NetworkPolicy objects for app.NetworkPolicies,
+// the allowFrom/allowTo shorthand on app.Services[].Ports and
+// app.Containers, and the app.DefaultDeny deny-all policy, if requested.
+func createNetworkPolicies(app *spec.App) ([]runtime.Object, error) {
+	var policies []runtime.Object
+
+	for _, np := range app.NetworkPolicies {
+		policies = append(policies, &networking_v1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        np.Name,
+				Labels:      app.Labels,
+				Annotations: app.Annotations,
+			},
+			Spec: np.NetworkPolicySpec,
+		})
+	}
+
+	shorthand, ok, err := shorthandNetworkPolicy(app)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		policies = append(policies, shorthand)
+	}
+
+	if app.DefaultDeny {
+		policies = append(policies, defaultDenyPolicy(app))
+	}
+
+	return policies, nil
+}
+
+// shorthandNetworkPolicy folds the allowFrom entries on every service port
+// and the allowTo entries on every container into a single NetworkPolicy
+// selecting this app's pods (app.Labels).
+func shorthandNetworkPolicy(app *spec.App) (*networking_v1.NetworkPolicy, bool, error) {
+	var ingress []networking_v1.NetworkPolicyIngressRule
+	var egress []networking_v1.NetworkPolicyEgressRule
+
+	for _, s := range app.Services {
+		for _, port := range s.Ports {
+			if len(port.AllowFrom) == 0 {
+				continue
+			}
+			peers, _, err := parsePeerShorthand(port.AllowFrom)
+			if err != nil {
+				return nil, false, errors.Wrapf(err, "service %q, allowFrom", s.Name)
+			}
+			ingress = append(ingress, networking_v1.NetworkPolicyIngressRule{
+				From:  peers,
+				Ports: []networking_v1.NetworkPolicyPort{tcpPort(targetPort(port.ServicePort))},
+			})
+		}
+	}
+
+	for _, c := range app.Containers {
+		if len(c.AllowTo) == 0 {
+			continue
+		}
+		peers, ports, err := parsePeerShorthand(c.AllowTo)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "container %q, allowTo", c.Name)
+		}
+		egress = append(egress, networking_v1.NetworkPolicyEgressRule{
+			To:    peers,
+			Ports: ports,
+		})
+	}
+
+	if len(ingress) == 0 && len(egress) == 0 {
+		return nil, false, nil
+	}
+
+	var policyTypes []networking_v1.PolicyType
+	if len(ingress) > 0 {
+		policyTypes = append(policyTypes, networking_v1.PolicyTypeIngress)
+	}
+	if len(egress) > 0 {
+		policyTypes = append(policyTypes, networking_v1.PolicyTypeEgress)
+	}
+
+	return &networking_v1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        app.Name,
+			Labels:      app.Labels,
+			Annotations: app.Annotations,
+		},
+		Spec: networking_v1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: app.Labels},
+			Ingress:     ingress,
+			Egress:      egress,
+			PolicyTypes: policyTypes,
+		},
+	}, true, nil
+}
+
+// parsePeerShorthand parses entries like "app=frontend" into a pod selector
+// and "port=5432" into an allowed port, mirroring how createServices already
+// parses the Endpoint shorthand on ServicePortMod.
+//
+// Each non-"port" entry becomes its own NetworkPolicyPeer rather than being
+// folded into one shared selector: "app=frontend" and "app=backend" in the
+// same list mean "allow from either", not "allow from app=backend" with
+// "app=frontend" silently discarded.
+func parsePeerShorthand(entries []string) ([]networking_v1.NetworkPolicyPeer, []networking_v1.NetworkPolicyPort, error) {
+	var peers []networking_v1.NetworkPolicyPeer
+	var ports []networking_v1.NetworkPolicyPort
+
+	for _, entry := range entries {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return nil, nil, errors.Errorf("invalid selector entry %q, expected key=value", entry)
+		}
+		key, value := kv[0], kv[1]
+
+		if key == "port" {
+			portNum, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "invalid port %q", value)
+			}
+			port := intstr.FromInt(portNum)
+			ports = append(ports, networking_v1.NetworkPolicyPort{Protocol: protoTCP(), Port: &port})
+			continue
+		}
+		peers = append(peers, networking_v1.NetworkPolicyPeer{
+			PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{key: value}},
+		})
+	}
+
+	return peers, ports, nil
+}
+
+func tcpPort(port intstr.IntOrString) networking_v1.NetworkPolicyPort {
+	p := port
+	return networking_v1.NetworkPolicyPort{Protocol: protoTCP(), Port: &p}
+}
+
+// targetPort returns sp.TargetPort, defaulting to sp.Port when TargetPort is
+// unset, the same way Kubernetes itself defaults a Service's targetPort.
+func targetPort(sp api_v1.ServicePort) intstr.IntOrString {
+	if sp.TargetPort == (intstr.IntOrString{}) {
+		return intstr.FromInt(int(sp.Port))
+	}
+	return sp.TargetPort
+}
+
+func protoTCP() *api_v1.Protocol {
+	p := api_v1.ProtocolTCP
+	return &p
+}
+
+// defaultDenyPolicy denies all ingress and egress traffic to this app's
+// pods that isn't otherwise allowed by another NetworkPolicy.
+func defaultDenyPolicy(app *spec.App) *networking_v1.NetworkPolicy {
+	return &networking_v1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        app.Name + "-default-deny",
+			Labels:      app.Labels,
+			Annotations: app.Annotations,
+		},
+		Spec: networking_v1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: app.Labels},
+			PolicyTypes: []networking_v1.PolicyType{
+				networking_v1.PolicyTypeIngress,
+				networking_v1.PolicyTypeEgress,
+			},
+		},
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2017 The Kedge Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/kedgeproject/kedge/pkg/spec"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+)
+
+func TestParsePeerShorthandOnePeerPerEntry(t *testing.T) {
+	peers, ports, err := parsePeerShorthand([]string{"app=frontend", "app=backend", "port=5432"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(peers) != 2 {
+		t.Fatalf("expected 2 distinct peers, got %+v", peers)
+	}
+	if peers[0].PodSelector.MatchLabels["app"] != "frontend" || peers[1].PodSelector.MatchLabels["app"] != "backend" {
+		t.Errorf("expected one selector per entry, got %+v", peers)
+	}
+
+	if len(ports) != 1 || ports[0].Port.IntValue() != 5432 {
+		t.Errorf("expected port 5432, got %+v", ports)
+	}
+}
+
+func TestParsePeerShorthandInvalidEntry(t *testing.T) {
+	if _, _, err := parsePeerShorthand([]string{"noequalsign"}); err == nil {
+		t.Fatal("expected an error for an entry without key=value")
+	}
+}
+
+func TestParsePeerShorthandInvalidPort(t *testing.T) {
+	if _, _, err := parsePeerShorthand([]string{"port=notanumber"}); err == nil {
+		t.Fatal("expected an error for a non-numeric port")
+	}
+}
+
+func TestTargetPortDefaultsToPort(t *testing.T) {
+	sp := api_v1.ServicePort{Port: 8080}
+
+	got := targetPort(sp)
+	if got != intstr.FromInt(8080) {
+		t.Errorf("expected targetPort to default to Port %d, got %+v", 8080, got)
+	}
+}
+
+func TestTargetPortUsesExplicitValue(t *testing.T) {
+	sp := api_v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(9090)}
+
+	got := targetPort(sp)
+	if got != intstr.FromInt(9090) {
+		t.Errorf("expected explicit targetPort to be kept, got %+v", got)
+	}
+}
+
+func TestShorthandNetworkPolicyNoneDefined(t *testing.T) {
+	app := &spec.App{Name: "app1"}
+
+	_, ok, err := shorthandNetworkPolicy(app)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Fatal("expected no shorthand policy when no allowFrom/allowTo entries are set")
+	}
+}
+
+func TestShorthandNetworkPolicyFromServiceAllowFrom(t *testing.T) {
+	app := &spec.App{
+		Name:   "app1",
+		Labels: map[string]string{"app": "app1"},
+		Services: []spec.ServiceSpecMod{
+			{
+				Name: "app1",
+				Ports: []spec.ServicePortMod{
+					{ServicePort: api_v1.ServicePort{Port: 8080}, AllowFrom: []string{"app=frontend"}},
+				},
+			},
+		},
+	}
+
+	policy, ok, err := shorthandNetworkPolicy(app)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected a shorthand policy to be generated")
+	}
+	if len(policy.Spec.Ingress) != 1 {
+		t.Fatalf("expected 1 ingress rule, got %+v", policy.Spec.Ingress)
+	}
+}
+
+func TestCreateNetworkPoliciesDefaultDeny(t *testing.T) {
+	app := &spec.App{Name: "app1", Labels: map[string]string{"app": "app1"}, DefaultDeny: true}
+
+	policies, err := createNetworkPolicies(app)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(policies) != 1 {
+		t.Fatalf("expected only the default-deny policy, got %+v", policies)
+	}
+}